@@ -0,0 +1,130 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of Cilium
+
+package filters
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/cilium/tetragon/api/v1/tetragon"
+	v1 "github.com/cilium/tetragon/pkg/oldhubble/api/v1"
+	"github.com/google/cel-go/cel"
+)
+
+// eventVar is the name CEL expressions use to refer to the event being filtered, e.g.
+// `event.process_exec.process.binary.endsWith("/curl")`.
+const eventVar = "event"
+
+// celEnv is the CEL type environment shared by every compiled CELFilter expression. It
+// registers the tetragon.GetEventsResponse descriptor so expressions can refer to any
+// field of an event through the "event" variable. Built lazily and once since
+// constructing a cel.Env walks the full proto descriptor graph.
+var celEnv = sync.OnceValues(func() (*cel.Env, error) {
+	return cel.NewEnv(
+		cel.Types(&tetragon.GetEventsResponse{}),
+		cel.Variable(eventVar, cel.ObjectType(string((&tetragon.GetEventsResponse{}).ProtoReflect().Descriptor().FullName()))),
+	)
+})
+
+// CELFilter filters events using a Common Expression Language (CEL) predicate
+// evaluated against the tetragon.GetEventsResponse message, e.g.
+// `event.process_exec.process.binary.endsWith("/curl") && event.process_exec.process.pod.namespace != "kube-system"`.
+// It complements the fixed-field tetragon.Filter (namespace, binary_regex, event_set,
+// ...) for predicates those fields cannot express: numeric comparisons, string
+// prefix/suffix matches and predicates spanning multiple fields.
+//
+// Expressions named in a tetragon.Filter's cel_expression are compiled once, at
+// build time, and the resulting cel.Program is cached and reused for every event,
+// since compiling a CEL expression is far more expensive than evaluating it.
+type CELFilter struct {
+	mu       sync.Mutex
+	programs map[string]cel.Program
+}
+
+// NewCELFilter creates an empty CELFilter ready to compile and cache CEL programs.
+func NewCELFilter() *CELFilter {
+	return &CELFilter{programs: make(map[string]cel.Program)}
+}
+
+func (c *CELFilter) program(expr string) (cel.Program, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if prg, ok := c.programs[expr]; ok {
+		return prg, nil
+	}
+
+	env, err := celEnv()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build CEL environment: %w", err)
+	}
+
+	ast, issues := env.Compile(expr)
+	if issues != nil && issues.Err() != nil {
+		return nil, fmt.Errorf("failed to compile CEL expression %q: %w", expr, issues.Err())
+	}
+
+	prg, err := env.Program(ast)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build CEL program for expression %q: %w", expr, err)
+	}
+
+	c.programs[expr] = prg
+	return prg, nil
+}
+
+func (c *CELFilter) matches(expr string, ev *v1.Event) bool {
+	prg, err := c.program(expr)
+	if err != nil {
+		return false
+	}
+
+	out, _, err := prg.Eval(map[string]any{eventVar: ev.GetEvent()})
+	if err != nil {
+		return false
+	}
+
+	matched, ok := out.Value().(bool)
+	return ok && matched
+}
+
+func filterByCELExpressions(c *CELFilter, exprs []string) FilterFunc {
+	return func(ev *v1.Event) bool {
+		for _, expr := range exprs {
+			if c.matches(expr, ev) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// OnBuildFilter compiles f.CelExpression, if any, and returns a FilterFunc that
+// matches an event when at least one of the expressions evaluates to true.
+func (c *CELFilter) OnBuildFilter(_ context.Context, f *tetragon.Filter) (FilterFuncs, error) {
+	if len(f.CelExpression) == 0 {
+		return nil, nil
+	}
+
+	// Compile eagerly so a malformed expression fails the agent at startup
+	// instead of silently never matching.
+	for _, expr := range f.CelExpression {
+		if _, err := c.program(expr); err != nil {
+			return nil, err
+		}
+	}
+
+	return FilterFuncs{filterByCELExpressions(c, f.CelExpression)}, nil
+}
+
+// ParseCELFilterList turns the values of the --cel-filter flag into a single
+// tetragon.Filter so it can be combined with the filters parsed by ParseFilterList;
+// like the other filter fields, multiple expressions are OR'd together.
+func ParseCELFilterList(exprs []string) *tetragon.Filter {
+	if len(exprs) == 0 {
+		return nil
+	}
+	return &tetragon.Filter{CelExpression: exprs}
+}
@@ -0,0 +1,56 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of Cilium
+
+package filters
+
+import (
+	"context"
+	"testing"
+
+	"github.com/cilium/tetragon/api/v1/tetragon"
+	v1 "github.com/cilium/tetragon/pkg/oldhubble/api/v1"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCELFilterMatch(t *testing.T) {
+	f := []*tetragon.Filter{{
+		CelExpression: []string{`event.process_exec.process.binary.endsWith("/curl")`},
+	}}
+
+	fl, err := BuildFilterList(context.Background(), f, []OnBuildFilter{NewCELFilter()})
+	assert.NoError(t, err)
+	ev := v1.Event{
+		Event: &tetragon.GetEventsResponse{
+			Event: &tetragon.GetEventsResponse_ProcessExec{
+				ProcessExec: &tetragon.ProcessExec{Process: &tetragon.Process{Binary: "/usr/bin/curl"}},
+			},
+		},
+	}
+	assert.True(t, fl.MatchOne(&ev))
+}
+
+func TestCELFilterNoMatch(t *testing.T) {
+	f := []*tetragon.Filter{{
+		CelExpression: []string{`event.process_exec.process.binary.endsWith("/curl")`},
+	}}
+
+	fl, err := BuildFilterList(context.Background(), f, []OnBuildFilter{NewCELFilter()})
+	assert.NoError(t, err)
+	ev := v1.Event{
+		Event: &tetragon.GetEventsResponse{
+			Event: &tetragon.GetEventsResponse_ProcessExec{
+				ProcessExec: &tetragon.ProcessExec{Process: &tetragon.Process{Binary: "/usr/bin/wget"}},
+			},
+		},
+	}
+	assert.False(t, fl.MatchOne(&ev))
+}
+
+func TestCELFilterInvalidExpression(t *testing.T) {
+	f := []*tetragon.Filter{{
+		CelExpression: []string{"this is not valid CEL"},
+	}}
+
+	_, err := BuildFilterList(context.Background(), f, []OnBuildFilter{NewCELFilter()})
+	assert.Error(t, err)
+}
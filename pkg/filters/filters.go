@@ -0,0 +1,141 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of Cilium
+
+package filters
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/cilium/tetragon/api/v1/tetragon"
+	v1 "github.com/cilium/tetragon/pkg/oldhubble/api/v1"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+// FilterFunc is a function that returns true if an event matches a filter.
+type FilterFunc func(ev *v1.Event) bool
+
+// FilterFuncs is a list of FilterFunc that must all match for an event to pass.
+type FilterFuncs []FilterFunc
+
+// MatchAll returns true if all the FilterFuncs match the event.
+func (fs FilterFuncs) MatchAll(ev *v1.Event) bool {
+	for _, f := range fs {
+		if !f(ev) {
+			return false
+		}
+	}
+	return true
+}
+
+// FilterList is a list of FilterFuncs. An event matches the list if it matches
+// at least one of the FilterFuncs (i.e. the individual tetragon.Filter entries
+// are OR'd together, while the checks inside a single tetragon.Filter are AND'd).
+type FilterList []FilterFuncs
+
+// MatchOne returns true if ev matches at least one of the filters in the list.
+// An empty list matches everything.
+func (fl FilterList) MatchOne(ev *v1.Event) bool {
+	if len(fl) == 0 {
+		return true
+	}
+	for _, f := range fl {
+		if f.MatchAll(ev) {
+			return true
+		}
+	}
+	return false
+}
+
+// OnBuildFilter is implemented by types that know how to turn a single
+// tetragon.Filter into the FilterFuncs that implement it.
+type OnBuildFilter interface {
+	OnBuildFilter(ctx context.Context, f *tetragon.Filter) (FilterFuncs, error)
+}
+
+// BuildFilterList builds a FilterList out of the given tetragon.Filter entries,
+// asking each of the filterFuncs builders to contribute its own FilterFuncs for
+// every entry.
+func BuildFilterList(ctx context.Context, filters []*tetragon.Filter, filterFuncs []OnBuildFilter) (FilterList, error) {
+	filterList := make(FilterList, 0, len(filters))
+	for _, filter := range filters {
+		var fs FilterFuncs
+		for _, f := range filterFuncs {
+			ff, err := f.OnBuildFilter(ctx, filter)
+			if err != nil {
+				return nil, err
+			}
+			fs = append(fs, ff...)
+		}
+		filterList = append(filterList, fs)
+	}
+	return filterList, nil
+}
+
+// ParseFilterList parses filters as a newline separated list of JSON encoded
+// tetragon.Filter messages, as used by the --{enable,disable}-*-filter family of
+// agent flags, and OR's in a tetragon.Filter built from celExprs (the values of
+// the --cel-filter flag) so the two flag families can be mixed. enablePidSetFilter
+// gates the pid_set field: since tracking a pid set filter keeps state for the
+// lifetime of the matched processes, callers must opt in before it is allowed to
+// appear in the filter list.
+func ParseFilterList(filters string, enablePidSetFilter bool, celExprs []string) ([]*tetragon.Filter, error) {
+	filterList := make([]*tetragon.Filter, 0)
+
+	if len(strings.TrimSpace(filters)) != 0 {
+		scanner := bufio.NewScanner(strings.NewReader(filters))
+		for scanner.Scan() {
+			line := strings.TrimSpace(scanner.Text())
+			if line == "" {
+				continue
+			}
+
+			filter := &tetragon.Filter{}
+			if err := protojson.Unmarshal([]byte(line), filter); err != nil {
+				return nil, fmt.Errorf("failed to parse filter %q: %w", line, err)
+			}
+			if !enablePidSetFilter && len(filter.PidSet) > 0 {
+				return nil, fmt.Errorf("pid_set filter is disabled, pass --enable-pid-set-filter to allow it")
+			}
+			filterList = append(filterList, filter)
+		}
+		if err := scanner.Err(); err != nil {
+			return nil, err
+		}
+	}
+
+	if celFilter := ParseCELFilterList(celExprs); celFilter != nil {
+		filterList = append(filterList, celFilter)
+	}
+
+	if len(filterList) == 0 {
+		return nil, nil
+	}
+	return filterList, nil
+}
+
+// EventTypeFilter filters events by their tetragon.EventType.
+type EventTypeFilter struct{}
+
+func filterByEventType(types []tetragon.EventType) FilterFunc {
+	return func(ev *v1.Event) bool {
+		evType := ev.GetEventType()
+		for _, t := range types {
+			if t == evType {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// OnBuildFilter builds a FilterFuncs that matches events against f.EventSet.
+func (e *EventTypeFilter) OnBuildFilter(_ context.Context, f *tetragon.Filter) (FilterFuncs, error) {
+	var fs FilterFuncs
+	if len(f.EventSet) > 0 {
+		fs = append(fs, filterByEventType(f.EventSet))
+	}
+	return fs, nil
+}
@@ -23,7 +23,7 @@ func TestParseFilterList(t *testing.T) {
 {"pid_set":[1]}
 {"event_set":["PROCESS_EXEC", "PROCESS_EXIT", "PROCESS_KPROBE", "PROCESS_TRACEPOINT"]}
 {"arguments_regex":["^--version$","^-a -b -c$"]}`
-	filterProto, err := ParseFilterList(f, true)
+	filterProto, err := ParseFilterList(f, true, nil)
 	assert.NoError(t, err)
 	if diff := cmp.Diff(
 		[]*tetragon.Filter{
@@ -41,16 +41,37 @@ func TestParseFilterList(t *testing.T) {
 	); diff != "" {
 		t.Errorf("filter mismatch (-want +got):\n%s", diff)
 	}
-	_, err = ParseFilterList("invalid filter json", true)
+	_, err = ParseFilterList("invalid filter json", true, nil)
 	assert.Error(t, err)
-	filterProto, err = ParseFilterList("", true)
+	filterProto, err = ParseFilterList("", true, nil)
 	assert.NoError(t, err)
 	assert.Empty(t, filterProto)
-	filterProto, err = ParseFilterList(`{"pid_set":[1]}`, false)
+	filterProto, err = ParseFilterList(`{"pid_set":[1]}`, false, nil)
 	assert.Error(t, err)
 	assert.Empty(t, filterProto)
 }
 
+// TestParseFilterListCELExprs exercises ParseCELFilterList's actual caller:
+// --cel-filter flag values (celExprs) are OR'd in as their own tetragon.Filter
+// alongside whatever --*-filter flags contributed via filters.
+func TestParseFilterListCELExprs(t *testing.T) {
+	filterProto, err := ParseFilterList("", true, []string{`event.process_exec.process.binary == "/usr/bin/curl"`})
+	assert.NoError(t, err)
+	if diff := cmp.Diff(
+		[]*tetragon.Filter{
+			{CelExpression: []string{`event.process_exec.process.binary == "/usr/bin/curl"`}},
+		},
+		filterProto,
+		cmpopts.IgnoreUnexported(tetragon.Filter{}),
+	); diff != "" {
+		t.Errorf("filter mismatch (-want +got):\n%s", diff)
+	}
+
+	filterProto, err = ParseFilterList(`{"namespace":["kube-system"]}`, true, []string{`event.process_exec.process.binary == "/usr/bin/curl"`})
+	assert.NoError(t, err)
+	assert.Len(t, filterProto, 2)
+}
+
 func TestEventTypeFilterMatch(t *testing.T) {
 	f := []*tetragon.Filter{{
 		EventSet: []tetragon.EventType{
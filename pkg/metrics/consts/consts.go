@@ -5,4 +5,9 @@ package consts
 
 const MetricsNamespace = "tetragon"
 
-var KnownMetricLabelFilters = []string{"namespace", "workload", "pod", "binary"}
+// KnownMetricLabelFilters is the set of labels operators may select to attach
+// to event metrics, meant to be exposed via a --metrics-label-filter agent
+// flag or its config file equivalent. See LabelFilterConfig for per-metric
+// allow-listing and cardinality protection on top of this set, and
+// SetLabelFilter/LabelFilter for how the metrics recording path consumes it.
+var KnownMetricLabelFilters = []string{"namespace", "workload", "pod", "binary", "container", "node", "command"}
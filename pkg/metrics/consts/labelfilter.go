@@ -0,0 +1,152 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of Tetragon
+
+package consts
+
+import (
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// OverflowLabelValue replaces the value of a bucketed label once its cardinality
+// threshold is exceeded, so that a single noisy binary or command can't grow a
+// metric's series count without bound.
+const OverflowLabelValue = "<overflow>"
+
+// bucketedLabels are the labels eligible for the hashed/bucketed overflow mode:
+// their values are taken directly from process data and can have unbounded
+// cardinality (as opposed to namespace/workload/pod, which are bounded by the
+// cluster's own cardinality).
+var bucketedLabels = map[string]bool{
+	"binary":  true,
+	"command": true,
+}
+
+// metricLabelOverflowTotal counts every time a bucketed label's value was
+// replaced with OverflowLabelValue because its cardinality threshold was
+// exceeded, so operators can tell a metric is losing granularity rather than
+// silently dropping data.
+var metricLabelOverflowTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Namespace: MetricsNamespace,
+	Name:      "metric_label_overflow_total",
+	Help:      "Total number of times a metric label value was replaced due to exceeding its cardinality threshold",
+}, []string{"label"})
+
+func init() {
+	prometheus.MustRegister(metricLabelOverflowTotal)
+}
+
+// LabelFilterConfig configures which of KnownMetricLabelFilters are attached to
+// which metrics, and protects bucketed labels (binary, command) from unbounded
+// cardinality growth.
+type LabelFilterConfig struct {
+	// EnabledLabels is the set of labels operators have turned on, a subset of
+	// KnownMetricLabelFilters.
+	EnabledLabels []string
+	// MetricLabelAllowList optionally restricts, per metric name, which of
+	// EnabledLabels are attached to that metric. A metric with no entry here
+	// gets every enabled label.
+	MetricLabelAllowList map[string][]string
+	// LabelCardinalityThreshold is the number of distinct values a bucketed
+	// label (binary, command) may take before further, unseen values collapse
+	// to OverflowLabelValue. Zero disables the threshold.
+	LabelCardinalityThreshold int
+}
+
+// NewLabelFilter creates a LabelFilterRunner enforcing cfg.
+func (cfg LabelFilterConfig) NewLabelFilter() *LabelFilterRunner {
+	enabled := make(map[string]bool, len(cfg.EnabledLabels))
+	for _, label := range cfg.EnabledLabels {
+		enabled[label] = true
+	}
+
+	return &LabelFilterRunner{
+		cfg:     cfg,
+		enabled: enabled,
+		seen:    make(map[string]map[string]struct{}),
+	}
+}
+
+// LabelFilterRunner applies a LabelFilterConfig to the labels attached to a given
+// metric, tracking the distinct values seen per bucketed label so it can enforce
+// LabelCardinalityThreshold.
+type LabelFilterRunner struct {
+	cfg     LabelFilterConfig
+	enabled map[string]bool
+
+	mu   sync.Mutex
+	seen map[string]map[string]struct{} // label -> seen values
+}
+
+// Allowed reports whether label should be attached to the metric named metricName.
+func (r *LabelFilterRunner) Allowed(metricName, label string) bool {
+	if !r.enabled[label] {
+		return false
+	}
+	allowList, ok := r.cfg.MetricLabelAllowList[metricName]
+	if !ok {
+		return true
+	}
+	for _, allowed := range allowList {
+		if allowed == label {
+			return true
+		}
+	}
+	return false
+}
+
+// Value returns the value to attach for label, replacing it with
+// OverflowLabelValue and incrementing metric_label_overflow_total when label is
+// bucketed and value would grow its cardinality past the configured threshold.
+func (r *LabelFilterRunner) Value(label, value string) string {
+	if !bucketedLabels[label] || r.cfg.LabelCardinalityThreshold <= 0 {
+		return value
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	values, ok := r.seen[label]
+	if !ok {
+		values = make(map[string]struct{})
+		r.seen[label] = values
+	}
+	if _, ok := values[value]; ok {
+		return value
+	}
+	if len(values) >= r.cfg.LabelCardinalityThreshold {
+		metricLabelOverflowTotal.WithLabelValues(label).Inc()
+		return OverflowLabelValue
+	}
+
+	values[value] = struct{}{}
+	return value
+}
+
+// defaultLabelFilter is the LabelFilterRunner the metrics recording path
+// consults via LabelFilter. It starts out enforcing the same fixed
+// namespace/workload/pod/binary set tetragon attached before label filtering
+// was configurable, and is replaced by SetLabelFilter once the agent parses
+// --metrics-label-filter or its config file equivalent.
+var defaultLabelFilter = LabelFilterConfig{EnabledLabels: []string{"namespace", "workload", "pod", "binary"}}.NewLabelFilter()
+
+var defaultLabelFilterMu sync.RWMutex
+
+// SetLabelFilter replaces the LabelFilterRunner returned by LabelFilter. Call
+// once at agent startup, after --metrics-label-filter and its config file
+// equivalent have been parsed into a LabelFilterConfig.
+func SetLabelFilter(cfg LabelFilterConfig) {
+	defaultLabelFilterMu.Lock()
+	defer defaultLabelFilterMu.Unlock()
+	defaultLabelFilter = cfg.NewLabelFilter()
+}
+
+// LabelFilter returns the LabelFilterRunner the metrics recording path should
+// use to decide which labels to attach to a metric and how to bucket
+// high-cardinality ones. Safe for concurrent use.
+func LabelFilter() *LabelFilterRunner {
+	defaultLabelFilterMu.RLock()
+	defer defaultLabelFilterMu.RUnlock()
+	return defaultLabelFilter
+}
@@ -0,0 +1,61 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of Tetragon
+
+package consts
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLabelFilterAllowed(t *testing.T) {
+	cfg := LabelFilterConfig{
+		EnabledLabels: []string{"namespace", "binary", "command"},
+		MetricLabelAllowList: map[string][]string{
+			"tetragon_events_total": {"namespace", "binary"},
+		},
+	}
+	lf := cfg.NewLabelFilter()
+
+	assert.True(t, lf.Allowed("tetragon_events_total", "namespace"))
+	assert.True(t, lf.Allowed("tetragon_events_total", "binary"))
+	assert.False(t, lf.Allowed("tetragon_events_total", "command"))
+	assert.False(t, lf.Allowed("tetragon_events_total", "pod"))
+
+	// A metric with no allow-list entry gets every enabled label.
+	assert.True(t, lf.Allowed("tetragon_debug_total", "command"))
+	// Labels the operator never enabled are never attached, regardless of allow-list.
+	assert.False(t, lf.Allowed("tetragon_debug_total", "pod"))
+}
+
+func TestLabelFilterValueOverflow(t *testing.T) {
+	cfg := LabelFilterConfig{
+		EnabledLabels:             []string{"binary"},
+		LabelCardinalityThreshold: 2,
+	}
+	lf := cfg.NewLabelFilter()
+
+	assert.Equal(t, "/bin/a", lf.Value("binary", "/bin/a"))
+	assert.Equal(t, "/bin/b", lf.Value("binary", "/bin/b"))
+	// A third distinct value exceeds the threshold and is bucketed.
+	assert.Equal(t, OverflowLabelValue, lf.Value("binary", "/bin/c"))
+	// Previously seen values keep passing through untouched.
+	assert.Equal(t, "/bin/a", lf.Value("binary", "/bin/a"))
+	// Non-bucketed labels are never overflowed.
+	assert.Equal(t, "anything", lf.Value("namespace", "anything"))
+}
+
+func TestLabelFilterDefaultAndSet(t *testing.T) {
+	// The default runner enforces the historical fixed label set.
+	assert.True(t, LabelFilter().Allowed("tetragon_events_total", "namespace"))
+	assert.False(t, LabelFilter().Allowed("tetragon_events_total", "container"))
+
+	SetLabelFilter(LabelFilterConfig{EnabledLabels: []string{"container"}})
+	t.Cleanup(func() {
+		SetLabelFilter(LabelFilterConfig{EnabledLabels: []string{"namespace", "workload", "pod", "binary"}})
+	})
+
+	assert.True(t, LabelFilter().Allowed("tetragon_events_total", "container"))
+	assert.False(t, LabelFilter().Allowed("tetragon_events_total", "namespace"))
+}
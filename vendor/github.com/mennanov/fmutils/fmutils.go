@@ -1,10 +1,17 @@
 package fmutils
 
 import (
+	"strconv"
+	"strings"
+
 	"google.golang.org/protobuf/proto"
 	"google.golang.org/protobuf/reflect/protoreflect"
 )
 
+// wildcardKey is the reserved NestedMask key that matches every field of a message,
+// every entry of a map, or every element of a list at that level.
+const wildcardKey = "*"
+
 // Filter keeps the msg fields that are listed in the paths and clears all the rest.
 //
 // This is a handy wrapper for NestedMask.Filter method.
@@ -29,42 +36,112 @@ func Overwrite(src, dest proto.Message, paths []string) {
 	NestedMaskFromPaths(paths).Overwrite(src, dest)
 }
 
+// Merge merges the fields listed in paths from src msg into dest msg following
+// google.protobuf.FieldMask update semantics.
+//
+// This is a handy wrapper for NestedMask.Merge method.
+// If the same paths are used to process multiple proto messages use NestedMask.Merge method directly.
+func Merge(src, dest proto.Message, paths []string, opts MergeOptions) {
+	NestedMaskFromPaths(paths).Merge(src, dest, opts)
+}
+
+// MergeOptions controls how NestedMask.Merge combines the fields of src into dest.
+type MergeOptions struct {
+	// ReplaceRepeated, when true, replaces a repeated or map field in dest with
+	// the corresponding field from src instead of appending/merging by key.
+	ReplaceRepeated bool
+	// ReplaceMessage, when true, replaces a singular message field (and map
+	// values that are messages) in dest wholesale with the value from src
+	// instead of recursively merging their fields.
+	ReplaceMessage bool
+}
+
 // NestedMask represents a field mask as a recursive map.
 type NestedMask map[string]NestedMask
 
 // NestedMaskFromPaths creates an instance of NestedMask for the given paths.
+//
+// Besides plain dotted field names, a path segment may be:
+//   - "*", a wildcard matching every field of a message or every entry of a
+//     list/map at that level, e.g. "labels.*" or "containers.*.image";
+//   - a bracketed, quoted map key, e.g. `labels["app.kubernetes.io/name"]`,
+//     to target a single map entry instead of the whole map;
+//   - a bracketed numeric list index, e.g. "containers[0].image", to target a
+//     single element of a repeated field.
+//
+// Wildcards, map keys and list indices are normalized into the same recursive
+// NestedMask shape as regular field names, stored under their literal string
+// form ("*", "0", "app.kubernetes.io/name", ...).
 func NestedMaskFromPaths(paths []string) NestedMask {
 	mask := make(NestedMask)
 	for _, path := range paths {
 		curr := mask
-		var letters []rune
-		for _, letter := range path {
-			if letter == '.' {
-				if len(letters) == 0 {
-					continue
-				}
-
-				key := string(letters)
-				c, ok := curr[key]
-				if !ok {
-					c = make(NestedMask)
-					curr[key] = c
-				}
-				curr = c
-				letters = nil
-				continue
+		for _, key := range splitPath(path) {
+			c, ok := curr[key]
+			if !ok {
+				c = make(NestedMask)
+				curr[key] = c
 			}
-			letters = append(letters, letter)
+			curr = c
 		}
+	}
+
+	return mask
+}
+
+// splitPath splits a single field mask path into its ordered segments, turning
+// dotted field names, "*" wildcards, bracketed numeric indices (e.g. "[0]") and
+// bracketed quoted map keys (e.g. `["app.kubernetes.io/name"]`) each into their
+// own segment.
+func splitPath(path string) []string {
+	var segments []string
+	var letters []rune
+	flush := func() {
 		if len(letters) != 0 {
-			key := string(letters)
-			if _, ok := curr[key]; !ok {
-				curr[key] = make(NestedMask)
+			segments = append(segments, string(letters))
+			letters = nil
+		}
+	}
+
+	runes := []rune(path)
+	for i := 0; i < len(runes); i++ {
+		switch runes[i] {
+		case '.':
+			flush()
+		case '[':
+			flush()
+			end := -1
+			for j := i + 1; j < len(runes); j++ {
+				if runes[j] == ']' {
+					end = j
+					break
+				}
 			}
+			if end < 0 {
+				// Unterminated bracket: fall back to treating it as a literal rune.
+				letters = append(letters, runes[i])
+				continue
+			}
+			key := strings.Trim(string(runes[i+1:end]), `"'`)
+			segments = append(segments, key)
+			i = end
+		default:
+			letters = append(letters, runes[i])
 		}
 	}
+	flush()
 
-	return mask
+	return segments
+}
+
+// lookup returns the sub-mask matching key, falling back to the wildcard entry
+// if key has no explicit entry of its own.
+func (mask NestedMask) lookup(key string) (NestedMask, bool) {
+	if m, ok := mask[key]; ok {
+		return m, true
+	}
+	m, ok := mask[wildcardKey]
+	return m, ok
 }
 
 // Filter keeps the msg fields that are listed in the paths and clears all the rest.
@@ -79,7 +156,7 @@ func (mask NestedMask) Filter(msg proto.Message) {
 
 	rft := msg.ProtoReflect()
 	rft.Range(func(fd protoreflect.FieldDescriptor, _ protoreflect.Value) bool {
-		m, ok := mask[string(fd.Name())]
+		m, ok := mask.lookup(string(fd.Name()))
 		if ok {
 			if len(m) == 0 {
 				return true
@@ -88,7 +165,7 @@ func (mask NestedMask) Filter(msg proto.Message) {
 			if fd.IsMap() {
 				xmap := rft.Get(fd).Map()
 				xmap.Range(func(mk protoreflect.MapKey, mv protoreflect.Value) bool {
-					if mi, ok := m[mk.String()]; ok {
+					if mi, ok := m.lookup(mk.String()); ok {
 						if i, ok := mv.Interface().(protoreflect.Message); ok && len(mi) > 0 {
 							mi.Filter(i.Interface())
 						}
@@ -101,7 +178,11 @@ func (mask NestedMask) Filter(msg proto.Message) {
 			} else if fd.IsList() {
 				list := rft.Get(fd).List()
 				for i := 0; i < list.Len(); i++ {
-					m.Filter(list.Get(i).Message().Interface())
+					if mi, ok := m.listElementMask(i); ok {
+						if elem, ok := list.Get(i).Interface().(protoreflect.Message); ok {
+							mi.Filter(elem.Interface())
+						}
+					}
 				}
 			} else if fd.Kind() == protoreflect.MessageKind {
 				m.Filter(rft.Get(fd).Message().Interface())
@@ -113,6 +194,35 @@ func (mask NestedMask) Filter(msg proto.Message) {
 	})
 }
 
+// listElementMask returns the sub-mask that applies to the list element at i: an
+// explicit numeric index (e.g. "0"), falling back to the wildcard entry, falling
+// back to the whole mask itself when it carries neither index nor wildcard keys
+// (i.e. it is a plain sub-field mask meant to apply uniformly to every element).
+func (mask NestedMask) listElementMask(i int) (NestedMask, bool) {
+	if mi, ok := mask.lookup(strconv.Itoa(i)); ok {
+		return mi, true
+	}
+	if mask.hasIndexSelectors() {
+		return nil, false
+	}
+	return mask, true
+}
+
+// hasIndexSelectors reports whether mask targets specific list elements, either by
+// numeric index or via a wildcard, as opposed to being a plain sub-field mask meant
+// to apply uniformly to every element of the list.
+func (mask NestedMask) hasIndexSelectors() bool {
+	if _, ok := mask[wildcardKey]; ok {
+		return true
+	}
+	for key := range mask {
+		if _, err := strconv.Atoi(key); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
 // Prune clears all the fields listed in paths from the given msg.
 //
 // All other fields are kept untouched. If the mask is empty no fields are cleared.
@@ -126,7 +236,7 @@ func (mask NestedMask) Prune(msg proto.Message) {
 
 	rft := msg.ProtoReflect()
 	rft.Range(func(fd protoreflect.FieldDescriptor, _ protoreflect.Value) bool {
-		m, ok := mask[string(fd.Name())]
+		m, ok := mask.lookup(string(fd.Name()))
 		if ok {
 			if len(m) == 0 {
 				rft.Clear(fd)
@@ -136,7 +246,7 @@ func (mask NestedMask) Prune(msg proto.Message) {
 			if fd.IsMap() {
 				xmap := rft.Get(fd).Map()
 				xmap.Range(func(mk protoreflect.MapKey, mv protoreflect.Value) bool {
-					if mi, ok := m[mk.String()]; ok {
+					if mi, ok := m.lookup(mk.String()); ok {
 						if i, ok := mv.Interface().(protoreflect.Message); ok && len(mi) > 0 {
 							mi.Prune(i.Interface())
 						} else {
@@ -149,7 +259,11 @@ func (mask NestedMask) Prune(msg proto.Message) {
 			} else if fd.IsList() {
 				list := rft.Get(fd).List()
 				for i := 0; i < list.Len(); i++ {
-					m.Prune(list.Get(i).Message().Interface())
+					if mi, ok := m.listElementMask(i); ok {
+						if elem, ok := list.Get(i).Interface().(protoreflect.Message); ok {
+							mi.Prune(elem.Interface())
+						}
+					}
 				}
 			} else if fd.Kind() == protoreflect.MessageKind {
 				m.Prune(rft.Get(fd).Message().Interface())
@@ -171,30 +285,329 @@ func (mask NestedMask) Overwrite(src, dest proto.Message) {
 }
 
 func (mask NestedMask) overwrite(src, dest protoreflect.Message) {
+	if wildcard, ok := mask[wildcardKey]; ok {
+		fields := dest.Descriptor().Fields()
+		for i := 0; i < fields.Len(); i++ {
+			destFD := fields.Get(i)
+			srcFD := src.Descriptor().Fields().ByName(destFD.Name())
+			if srcFD == nil {
+				continue
+			}
+			// An explicit entry for this field name takes precedence over the wildcard.
+			if _, explicit := mask[string(destFD.Name())]; explicit {
+				continue
+			}
+			overwriteField(src, dest, srcFD, destFD, wildcard)
+		}
+	}
+
 	for k, v := range mask {
+		if k == wildcardKey {
+			continue
+		}
 		srcFD := src.Descriptor().Fields().ByName(protoreflect.Name(k))
 		destFD := dest.Descriptor().Fields().ByName(protoreflect.Name(k))
 		if srcFD == nil || destFD == nil {
 			continue
 		}
+		overwriteField(src, dest, srcFD, destFD, v)
+	}
+}
 
+func overwriteField(src, dest protoreflect.Message, srcFD, destFD protoreflect.FieldDescriptor, v NestedMask) {
+	switch {
+	case srcFD.IsList():
+		overwriteList(src, dest, srcFD, destFD, v)
+	case srcFD.IsMap():
+		overwriteMap(src, dest, srcFD, destFD, v)
+	case len(v) == 0:
 		// Leaf mask -> copy value from src to dest
-		if len(v) == 0 {
-			if srcFD.Kind() == destFD.Kind() { // TODO: Full type equality check
-				val := src.Get(srcFD)
-				if isValid(srcFD, val) {
-					dest.Set(destFD, val)
-				} else {
-					dest.Clear(destFD)
-				}
+		if srcFD.Kind() == destFD.Kind() { // TODO: Full type equality check
+			val := src.Get(srcFD)
+			if isValid(srcFD, val) {
+				dest.Set(destFD, val)
+			} else {
+				dest.Clear(destFD)
+			}
+		}
+	case srcFD.Kind() == protoreflect.MessageKind:
+		// If dest field is nil
+		if !dest.Get(destFD).Message().IsValid() {
+			dest.Set(destFD, protoreflect.ValueOf(dest.Get(destFD).Message().New()))
+		}
+		v.overwrite(src.Get(srcFD).Message(), dest.Get(destFD).Message())
+	}
+}
+
+// overwriteList overwrites dest's repeated field from src. A leaf mask (no indices)
+// copies the whole list, matching the historical behavior. A mask carrying numeric
+// indices and/or a wildcard only overwrites the matching elements, leaving the rest
+// of dest untouched.
+func overwriteList(src, dest protoreflect.Message, srcFD, destFD protoreflect.FieldDescriptor, v NestedMask) {
+	if len(v) == 0 {
+		dest.Set(destFD, src.Get(srcFD))
+		return
+	}
+
+	srcList := src.Get(srcFD).List()
+	destList := dest.Mutable(destFD).List()
+	for i := 0; i < srcList.Len() && i < destList.Len(); i++ {
+		mi, ok := v.listElementMask(i)
+		if !ok {
+			continue
+		}
+		if len(mi) == 0 {
+			destList.Set(i, srcList.Get(i))
+			continue
+		}
+		mi.overwrite(srcList.Get(i).Message(), destList.Get(i).Message())
+	}
+}
+
+// overwriteMap overwrites dest's map field from src by key. A leaf mask (no keys)
+// copies the whole map, matching the historical behavior. A mask naming specific
+// keys and/or a wildcard only overwrites the matching entries, leaving the rest of
+// dest untouched.
+func overwriteMap(src, dest protoreflect.Message, srcFD, destFD protoreflect.FieldDescriptor, v NestedMask) {
+	if len(v) == 0 {
+		dest.Set(destFD, src.Get(srcFD))
+		return
+	}
+
+	srcMap := src.Get(srcFD).Map()
+	destMap := dest.Mutable(destFD).Map()
+	overwriteMapEntry := func(mk protoreflect.MapKey, mi NestedMask) {
+		srcVal := srcMap.Get(mk)
+		if !srcVal.IsValid() {
+			return
+		}
+		if len(mi) == 0 {
+			destMap.Set(mk, srcVal)
+			return
+		}
+		destVal := destMap.Get(mk)
+		if !destVal.IsValid() || !destVal.Message().IsValid() {
+			destVal = protoreflect.ValueOf(srcVal.Message().New())
+			destMap.Set(mk, destVal)
+		}
+		mi.overwrite(srcVal.Message(), destVal.Message())
+	}
+
+	if wildcard, ok := v[wildcardKey]; ok {
+		srcMap.Range(func(mk protoreflect.MapKey, _ protoreflect.Value) bool {
+			if _, explicit := v[mk.String()]; !explicit {
+				overwriteMapEntry(mk, wildcard)
 			}
-		} else if srcFD.Kind() == protoreflect.MessageKind {
-			// If dest field is nil
+			return true
+		})
+	}
+	for key, mi := range v {
+		if key == wildcardKey {
+			continue
+		}
+		overwriteMapEntry(protoreflect.ValueOf(key).MapKey(), mi)
+	}
+}
+
+// Merge merges the fields listed in the mask from src msg into dest msg, following
+// the update semantics described for google.protobuf.FieldMask: scalar fields in src
+// replace the corresponding field in dest; singular message fields in src are merged
+// into dest field by field; repeated fields in src are appended to dest; map fields in
+// src are merged into dest by key, recursing into message values. Set opts.ReplaceRepeated and/or opts.ReplaceMessage to replace
+// repeated/map fields or message fields wholesale instead.
+// Paths are assumed to be valid and normalized otherwise the function may panic.
+func (mask NestedMask) Merge(src, dest proto.Message, opts MergeOptions) {
+	mask.merge(src.ProtoReflect(), dest.ProtoReflect(), opts)
+}
+
+func (mask NestedMask) merge(src, dest protoreflect.Message, opts MergeOptions) {
+	if wildcard, ok := mask[wildcardKey]; ok {
+		fields := dest.Descriptor().Fields()
+		for i := 0; i < fields.Len(); i++ {
+			destFD := fields.Get(i)
+			srcFD := src.Descriptor().Fields().ByName(destFD.Name())
+			if srcFD == nil {
+				continue
+			}
+			// An explicit entry for this field name takes precedence over the wildcard.
+			if _, explicit := mask[string(destFD.Name())]; explicit {
+				continue
+			}
+			mergeField(src, dest, srcFD, destFD, wildcard, opts)
+		}
+	}
+
+	for k, v := range mask {
+		if k == wildcardKey {
+			continue
+		}
+		srcFD := src.Descriptor().Fields().ByName(protoreflect.Name(k))
+		destFD := dest.Descriptor().Fields().ByName(protoreflect.Name(k))
+		if srcFD == nil || destFD == nil {
+			continue
+		}
+		mergeField(src, dest, srcFD, destFD, v, opts)
+	}
+}
+
+func mergeField(src, dest protoreflect.Message, srcFD, destFD protoreflect.FieldDescriptor, v NestedMask, opts MergeOptions) {
+	if len(v) != 0 && srcFD.Kind() == protoreflect.MessageKind && !srcFD.IsMap() && !srcFD.IsList() {
+		// Nested mask -> only merge the listed sub-fields of the message.
+		if !dest.Get(destFD).Message().IsValid() {
+			dest.Set(destFD, protoreflect.ValueOf(dest.Get(destFD).Message().New()))
+		}
+		v.merge(src.Get(srcFD).Message(), dest.Get(destFD).Message(), opts)
+		return
+	}
+
+	switch {
+	case srcFD.IsList():
+		mergeList(src, dest, srcFD, destFD, v, opts)
+	case srcFD.IsMap():
+		mergeMap(src, dest, srcFD, destFD, v, opts)
+	case srcFD.Kind() == protoreflect.MessageKind && !opts.ReplaceMessage:
+		// Leaf mask on a message field -> merge all of src's fields into dest
+		// rather than replacing dest outright.
+		if !dest.Get(destFD).Message().IsValid() {
+			dest.Set(destFD, protoreflect.ValueOf(dest.Get(destFD).Message().New()))
+		}
+		mergeAllFields(src.Get(srcFD).Message(), dest.Get(destFD).Message(), opts)
+	default:
+		if srcFD.Kind() == destFD.Kind() { // TODO: Full type equality check
+			val := src.Get(srcFD)
+			if isValid(srcFD, val) {
+				dest.Set(destFD, val)
+			} else {
+				dest.Clear(destFD)
+			}
+		}
+	}
+}
+
+// mergeAllFields merges every populated field of src into dest according to opts.
+// It is used to merge a whole submessage selected by a leaf mask entry, where every
+// field of src (not just the ones named in the mask) is considered selected.
+func mergeAllFields(src, dest protoreflect.Message, opts MergeOptions) {
+	src.Range(func(fd protoreflect.FieldDescriptor, _ protoreflect.Value) bool {
+		destFD := dest.Descriptor().Fields().ByName(fd.Name())
+		if destFD == nil {
+			return true
+		}
+
+		switch {
+		case fd.IsList():
+			mergeList(src, dest, fd, destFD, nil, opts)
+		case fd.IsMap():
+			mergeMap(src, dest, fd, destFD, nil, opts)
+		case fd.Kind() == protoreflect.MessageKind && !opts.ReplaceMessage:
 			if !dest.Get(destFD).Message().IsValid() {
 				dest.Set(destFD, protoreflect.ValueOf(dest.Get(destFD).Message().New()))
 			}
-			v.overwrite(src.Get(srcFD).Message(), dest.Get(destFD).Message())
+			mergeAllFields(src.Get(fd).Message(), dest.Get(destFD).Message(), opts)
+		default:
+			dest.Set(destFD, src.Get(fd))
+		}
+		return true
+	})
+}
+
+// mergeList merges the src repeated field into dest: a leaf submask v (no
+// indices) appends every element of src to dest, or replaces dest wholesale
+// when opts.ReplaceRepeated is set. A submask carrying numeric indices and/or
+// a wildcard only merges the matching elements in place, mirroring
+// overwriteList, so a path like "containers[0]" isn't silently widened into
+// "merge the whole list".
+func mergeList(src, dest protoreflect.Message, srcFD, destFD protoreflect.FieldDescriptor, v NestedMask, opts MergeOptions) {
+	if opts.ReplaceRepeated && len(v) == 0 {
+		dest.Set(destFD, src.Get(srcFD))
+		return
+	}
+
+	srcList := src.Get(srcFD).List()
+	destList := dest.Mutable(destFD).List()
+
+	if len(v) == 0 {
+		for i := 0; i < srcList.Len(); i++ {
+			destList.Append(srcList.Get(i))
+		}
+		return
+	}
+
+	for i := 0; i < srcList.Len() && i < destList.Len(); i++ {
+		mi, ok := v.listElementMask(i)
+		if !ok {
+			continue
+		}
+		if len(mi) == 0 {
+			destList.Set(i, srcList.Get(i))
+			continue
+		}
+		mi.merge(srcList.Get(i).Message(), destList.Get(i).Message(), opts)
+	}
+}
+
+// mergeMap merges the entries of the src map field into dest by key, or
+// replaces dest wholesale when opts.ReplaceRepeated is set. Entries whose
+// value is a message are merged field by field unless opts.ReplaceMessage is
+// set. A submask v naming specific keys and/or a wildcard only merges the
+// matching entries, mirroring overwriteMap; a leaf submask (no keys) merges
+// every entry.
+func mergeMap(src, dest protoreflect.Message, srcFD, destFD protoreflect.FieldDescriptor, v NestedMask, opts MergeOptions) {
+	if opts.ReplaceRepeated && len(v) == 0 {
+		dest.Set(destFD, src.Get(srcFD))
+		return
+	}
+
+	srcMap := src.Get(srcFD).Map()
+	destMap := dest.Mutable(destFD).Map()
+	mergeMapEntry := func(mk protoreflect.MapKey, mi NestedMask) {
+		srcVal := srcMap.Get(mk)
+		if !srcVal.IsValid() {
+			return
+		}
+		srcEntry, ok := srcVal.Interface().(protoreflect.Message)
+		if !ok || opts.ReplaceMessage {
+			destMap.Set(mk, srcVal)
+			return
+		}
+
+		destVal := destMap.Get(mk)
+		var destEntry protoreflect.Message
+		if destVal.IsValid() {
+			destEntry = destVal.Message()
+		}
+		if destEntry == nil || !destEntry.IsValid() {
+			destEntry = srcEntry.New()
+			destMap.Set(mk, protoreflect.ValueOf(destEntry))
+		}
+		if len(mi) == 0 {
+			mergeAllFields(srcEntry, destEntry, opts)
+		} else {
+			mi.merge(srcEntry, destEntry, opts)
+		}
+	}
+
+	if len(v) == 0 {
+		srcMap.Range(func(mk protoreflect.MapKey, _ protoreflect.Value) bool {
+			mergeMapEntry(mk, nil)
+			return true
+		})
+		return
+	}
+
+	if wildcard, ok := v[wildcardKey]; ok {
+		srcMap.Range(func(mk protoreflect.MapKey, _ protoreflect.Value) bool {
+			if _, explicit := v[mk.String()]; !explicit {
+				mergeMapEntry(mk, wildcard)
+			}
+			return true
+		})
+	}
+	for key, mi := range v {
+		if key == wildcardKey {
+			continue
 		}
+		mergeMapEntry(protoreflect.ValueOf(key).MapKey(), mi)
 	}
 }
 
@@ -0,0 +1,293 @@
+package fmutils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+func TestSplitPath(t *testing.T) {
+	testCases := []struct {
+		path     string
+		expected []string
+	}{
+		{"foo.bar", []string{"foo", "bar"}},
+		{"foo.*", []string{"foo", "*"}},
+		{`labels["app.kubernetes.io/name"]`, []string{"labels", "app.kubernetes.io/name"}},
+		{"labels['team']", []string{"labels", "team"}},
+		{"containers[0].image", []string{"containers", "0", "image"}},
+		{"containers.*.image", []string{"containers", "*", "image"}},
+	}
+
+	for _, tc := range testCases {
+		assert.Equal(t, tc.expected, splitPath(tc.path), tc.path)
+	}
+}
+
+func TestNestedMaskFromPathsWildcardsKeysAndIndices(t *testing.T) {
+	mask := NestedMaskFromPaths([]string{
+		`labels["app.kubernetes.io/name"]`,
+		"containers[0].image",
+		"containers.*.name",
+	})
+
+	assert.Contains(t, mask, "labels")
+	assert.Contains(t, mask["labels"], "app.kubernetes.io/name")
+
+	assert.Contains(t, mask, "containers")
+	assert.Contains(t, mask["containers"], "0")
+	assert.Contains(t, mask["containers"]["0"], "image")
+	assert.Contains(t, mask["containers"], "*")
+	assert.Contains(t, mask["containers"]["*"], "name")
+}
+
+// structpb.Value/Struct/ListValue stand in for an arbitrary proto message with
+// a scalar oneof field, a singular message field, a repeated message field and
+// a map<string, message> field, which is exactly the shape Merge needs to
+// exercise.
+
+func TestMergeScalar(t *testing.T) {
+	src := structpb.NewStringValue("hello")
+	dest := structpb.NewNumberValue(1)
+
+	NestedMask{"string_value": {}}.Merge(src, dest, MergeOptions{})
+
+	assert.Equal(t, "hello", dest.GetStringValue())
+}
+
+func TestMergeListAppend(t *testing.T) {
+	src := &structpb.ListValue{Values: []*structpb.Value{structpb.NewStringValue("b")}}
+	dest := &structpb.ListValue{Values: []*structpb.Value{structpb.NewStringValue("a")}}
+
+	NestedMask{"values": {}}.Merge(src, dest, MergeOptions{})
+
+	if assert.Len(t, dest.Values, 2) {
+		assert.Equal(t, "a", dest.Values[0].GetStringValue())
+		assert.Equal(t, "b", dest.Values[1].GetStringValue())
+	}
+}
+
+func TestMergeListReplaceRepeated(t *testing.T) {
+	src := &structpb.ListValue{Values: []*structpb.Value{structpb.NewStringValue("b")}}
+	dest := &structpb.ListValue{Values: []*structpb.Value{structpb.NewStringValue("a")}}
+
+	NestedMask{"values": {}}.Merge(src, dest, MergeOptions{ReplaceRepeated: true})
+
+	if assert.Len(t, dest.Values, 1) {
+		assert.Equal(t, "b", dest.Values[0].GetStringValue())
+	}
+}
+
+func TestMergeMapByKey(t *testing.T) {
+	src := &structpb.Struct{Fields: map[string]*structpb.Value{
+		"a": structpb.NewStringValue("src-a"),
+		"b": structpb.NewStringValue("src-b"),
+	}}
+	dest := &structpb.Struct{Fields: map[string]*structpb.Value{
+		"a": structpb.NewStringValue("dest-a"),
+		"c": structpb.NewStringValue("dest-c"),
+	}}
+
+	NestedMask{"fields": {}}.Merge(src, dest, MergeOptions{})
+
+	assert.Equal(t, "src-a", dest.Fields["a"].GetStringValue())
+	assert.Equal(t, "src-b", dest.Fields["b"].GetStringValue())
+	assert.Equal(t, "dest-c", dest.Fields["c"].GetStringValue())
+}
+
+func TestMergeMapReplaceRepeated(t *testing.T) {
+	src := &structpb.Struct{Fields: map[string]*structpb.Value{"a": structpb.NewStringValue("src-a")}}
+	dest := &structpb.Struct{Fields: map[string]*structpb.Value{"c": structpb.NewStringValue("dest-c")}}
+
+	NestedMask{"fields": {}}.Merge(src, dest, MergeOptions{ReplaceRepeated: true})
+
+	assert.Equal(t, []string{"a"}, mapKeys(dest.Fields))
+}
+
+func TestMergeNestedMessage(t *testing.T) {
+	src := structpb.NewStructValue(&structpb.Struct{Fields: map[string]*structpb.Value{
+		"a": structpb.NewStringValue("src-a"),
+	}})
+	dest := structpb.NewStructValue(&structpb.Struct{Fields: map[string]*structpb.Value{
+		"b": structpb.NewStringValue("dest-b"),
+	}})
+
+	NestedMask{"struct_value": {"fields": {}}}.Merge(src, dest, MergeOptions{})
+
+	assert.Equal(t, "src-a", dest.GetStructValue().Fields["a"].GetStringValue())
+	assert.Equal(t, "dest-b", dest.GetStructValue().Fields["b"].GetStringValue())
+}
+
+func TestMergeReplaceMessage(t *testing.T) {
+	src := structpb.NewStructValue(&structpb.Struct{Fields: map[string]*structpb.Value{
+		"a": structpb.NewStringValue("src-a"),
+	}})
+	dest := structpb.NewStructValue(&structpb.Struct{Fields: map[string]*structpb.Value{
+		"b": structpb.NewStringValue("dest-b"),
+	}})
+
+	NestedMask{"struct_value": {}}.Merge(src, dest, MergeOptions{ReplaceMessage: true})
+
+	assert.Equal(t, []string{"a"}, mapKeys(dest.GetStructValue().Fields))
+}
+
+func mapKeys(m map[string]*structpb.Value) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+// Filter/Prune/Overwrite/Merge with wildcard, map-key and list-index paths.
+
+func TestFilterMapKey(t *testing.T) {
+	msg := &structpb.Struct{Fields: map[string]*structpb.Value{
+		"a": structpb.NewStringValue("x"),
+		"b": structpb.NewStringValue("y"),
+	}}
+
+	NestedMaskFromPaths([]string{`fields["a"]`}).Filter(msg)
+
+	assert.Contains(t, msg.Fields, "a")
+	assert.NotContains(t, msg.Fields, "b")
+}
+
+func TestFilterMapOfMessageWildcard(t *testing.T) {
+	msg := &structpb.Struct{Fields: map[string]*structpb.Value{
+		"a": structpb.NewStructValue(&structpb.Struct{Fields: map[string]*structpb.Value{
+			"keep": structpb.NewStringValue("x"),
+			"drop": structpb.NewStringValue("y"),
+		}}),
+	}}
+
+	NestedMaskFromPaths([]string{"fields.*.struct_value.fields.keep"}).Filter(msg)
+
+	fields := msg.Fields["a"].GetStructValue().GetFields()
+	assert.Contains(t, fields, "keep")
+	assert.NotContains(t, fields, "drop")
+}
+
+func TestFilterListOfMessageIndex(t *testing.T) {
+	msg := &structpb.ListValue{Values: []*structpb.Value{
+		structpb.NewStructValue(&structpb.Struct{Fields: map[string]*structpb.Value{
+			"keep": structpb.NewStringValue("x"),
+			"drop": structpb.NewStringValue("y"),
+		}}),
+	}}
+
+	NestedMaskFromPaths([]string{"values[0].struct_value.fields.keep"}).Filter(msg)
+
+	fields := msg.Values[0].GetStructValue().GetFields()
+	assert.Contains(t, fields, "keep")
+	assert.NotContains(t, fields, "drop")
+}
+
+func TestFilterListIndexOnScalarElementDoesNotPanic(t *testing.T) {
+	fm := &fieldmaskpb.FieldMask{Paths: []string{"a", "b", "c"}}
+
+	assert.NotPanics(t, func() {
+		NestedMaskFromPaths([]string{"paths[0]"}).Filter(fm)
+	})
+	assert.Equal(t, []string{"a", "b", "c"}, fm.GetPaths())
+}
+
+func TestPruneMapKey(t *testing.T) {
+	msg := &structpb.Struct{Fields: map[string]*structpb.Value{
+		"a": structpb.NewStringValue("x"),
+		"b": structpb.NewStringValue("y"),
+	}}
+
+	NestedMaskFromPaths([]string{`fields["a"]`}).Prune(msg)
+
+	assert.NotContains(t, msg.Fields, "a")
+	assert.Contains(t, msg.Fields, "b")
+}
+
+func TestPruneListIndexOnScalarElementDoesNotPanic(t *testing.T) {
+	fm := &fieldmaskpb.FieldMask{Paths: []string{"a", "b", "c"}}
+
+	assert.NotPanics(t, func() {
+		NestedMaskFromPaths([]string{"paths[0]"}).Prune(fm)
+	})
+	assert.Equal(t, []string{"a", "b", "c"}, fm.GetPaths())
+}
+
+func TestOverwriteListIndex(t *testing.T) {
+	src := &structpb.ListValue{Values: []*structpb.Value{
+		structpb.NewStringValue("src-0"),
+		structpb.NewStringValue("src-1"),
+	}}
+	dest := &structpb.ListValue{Values: []*structpb.Value{
+		structpb.NewStringValue("dest-0"),
+		structpb.NewStringValue("dest-1"),
+	}}
+
+	NestedMaskFromPaths([]string{"values[1]"}).Overwrite(src, dest)
+
+	assert.Equal(t, "dest-0", dest.Values[0].GetStringValue())
+	assert.Equal(t, "src-1", dest.Values[1].GetStringValue())
+}
+
+// TestMergeListElementSelector guards against Merge silently widening an
+// index selector into "merge the whole list", which is what happens if the
+// submask isn't threaded into mergeList.
+func TestMergeListElementSelector(t *testing.T) {
+	src := &structpb.ListValue{Values: []*structpb.Value{
+		structpb.NewStringValue("src-0"),
+		structpb.NewStringValue("src-1"),
+	}}
+	dest := &structpb.ListValue{Values: []*structpb.Value{
+		structpb.NewStringValue("dest-0"),
+		structpb.NewStringValue("dest-1"),
+	}}
+
+	NestedMaskFromPaths([]string{"values[0]"}).Merge(src, dest, MergeOptions{})
+
+	if assert.Len(t, dest.Values, 2) {
+		assert.Equal(t, "src-0", dest.Values[0].GetStringValue())
+		assert.Equal(t, "dest-1", dest.Values[1].GetStringValue())
+	}
+}
+
+// TestMergeMapKeySelector guards against Merge silently widening a key
+// selector into "merge every entry", which is what happens if the submask
+// isn't threaded into mergeMap.
+func TestMergeMapKeySelector(t *testing.T) {
+	src := &structpb.Struct{Fields: map[string]*structpb.Value{
+		"a": structpb.NewStringValue("src-a"),
+		"b": structpb.NewStringValue("src-b"),
+	}}
+	dest := &structpb.Struct{Fields: map[string]*structpb.Value{
+		"a": structpb.NewStringValue("dest-a"),
+		"c": structpb.NewStringValue("dest-c"),
+	}}
+
+	NestedMaskFromPaths([]string{`fields["a"]`}).Merge(src, dest, MergeOptions{})
+
+	assert.Equal(t, "src-a", dest.Fields["a"].GetStringValue())
+	assert.Equal(t, "dest-c", dest.Fields["c"].GetStringValue())
+	assert.NotContains(t, dest.Fields, "b")
+}
+
+// TestMergeMessageFieldWildcard guards against merge() silently dropping a
+// field-name wildcard: unlike the "*" entries mergeList/mergeMap already
+// understand (matching a list index or map key), this "*" matches every
+// *field* of the enclosing message, the way overwrite() special-cases
+// mask[wildcardKey]. Before that sweep was ported into merge(), "struct_value.*"
+// looked up a field literally named "*", found none, and merged nothing.
+func TestMergeMessageFieldWildcard(t *testing.T) {
+	src := structpb.NewStructValue(&structpb.Struct{Fields: map[string]*structpb.Value{
+		"a": structpb.NewStringValue("src-a"),
+	}})
+	dest := structpb.NewStructValue(&structpb.Struct{Fields: map[string]*structpb.Value{
+		"b": structpb.NewStringValue("dest-b"),
+	}})
+
+	NestedMaskFromPaths([]string{"struct_value.*"}).Merge(src, dest, MergeOptions{})
+
+	assert.Equal(t, "src-a", dest.GetStructValue().Fields["a"].GetStringValue())
+	assert.Equal(t, "dest-b", dest.GetStructValue().Fields["b"].GetStringValue())
+}